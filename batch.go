@@ -0,0 +1,100 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlxNamedExecer is satisfied by the raw sqlx handles (*sqlx.DB, *sqlx.Tx).
+// Batch execution runs against the raw handle rather than through the
+// Querier-wrapping dbx/tx themselves, since it manages its own single span
+// across every chunk.
+type sqlxNamedExecer interface {
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+func (d *dbx) NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error) {
+	return namedExecBatchContext(ctx, d.DB, d.tracer, d.driver, d.commenter, d.tracingOpts, query, args, batchSize)
+}
+
+func (t *tx) NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error) {
+	return namedExecBatchContext(ctx, t.TX, t.tracer, t.driver, t.commenter, t.tracingOpts, query, args, batchSize)
+}
+
+// namedExecBatchContext executes query once per chunk of batchSize elements
+// of args. Each chunk is passed straight to sqlx's NamedExecContext, which
+// natively expands a slice argument into a single multi-row VALUES
+// statement; chunking keeps any one statement well under a driver's
+// placeholder limit. RowsAffected is aggregated across chunks and reported
+// as a single db.result.affected_rows attribute on one span.
+func namedExecBatchContext(ctx context.Context, exec sqlxNamedExecer, tracer trace.Tracer, driver string, commenter *sqlCommenter, tracingOpts *TracingOptions, query string, args []interface{}, batchSize int) (sql.Result, error) {
+	ctx, span := newSpan(ctx, driver, query, tracer, tracingOpts)
+	defer span.End()
+
+	chunks := chunkBatchArgs(args, batchSize)
+	span.SetAttributes(
+		attribute.Int("db.batch.size", batchSize),
+		attribute.Int("db.batch.count", len(chunks)),
+	)
+
+	var (
+		result  sql.Result
+		total   int64
+		lastErr error
+	)
+
+	for _, chunk := range chunks {
+		r, err := exec.NamedExecContext(ctx, commenter.inject(ctx, query), chunk)
+		if err != nil {
+			lastErr = err
+			break
+		}
+
+		result = r
+		if n, err := r.RowsAffected(); err == nil {
+			total += n
+		}
+	}
+
+	if lastErr != nil {
+		span.RecordError(lastErr)
+		return result, lastErr
+	}
+
+	span.SetAttributes(attribute.Int64("db.result.affected_rows", total))
+
+	return &batchResult{Result: result, rowsAffected: total}, nil
+}
+
+// batchResult adapts the last chunk's sql.Result to report RowsAffected
+// aggregated across every chunk, so a caller inspecting the returned Result
+// sees the same total as the db.result.affected_rows span attribute instead
+// of silently undercounting to just the final chunk.
+type batchResult struct {
+	sql.Result
+	rowsAffected int64
+}
+
+func (r *batchResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func chunkBatchArgs(args []interface{}, batchSize int) [][]interface{} {
+	if batchSize <= 0 || batchSize >= len(args) {
+		return [][]interface{}{args}
+	}
+
+	chunks := make([][]interface{}, 0, (len(args)+batchSize-1)/batchSize)
+	for i := 0; i < len(args); i += batchSize {
+		end := i + batchSize
+		if end > len(args) {
+			end = len(args)
+		}
+		chunks = append(chunks, args[i:end])
+	}
+
+	return chunks
+}
@@ -0,0 +1,82 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeBatchResult lets tests control RowsAffected per simulated chunk
+// without needing a real driver connection.
+type fakeBatchResult struct {
+	rowsAffected int64
+}
+
+func (r fakeBatchResult) LastInsertId() (int64, error) { return 0, nil }
+func (r fakeBatchResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// fakeNamedExecer returns one result per call, in order, simulating a chunk
+// of a batched NamedExecContext.
+type fakeNamedExecer struct {
+	results []sql.Result
+	calls   int
+}
+
+func (f *fakeNamedExecer) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	r := f.results[f.calls]
+	f.calls++
+	return r, nil
+}
+
+func TestNamedExecBatchContextAggregatesRowsAffectedAcrossChunks(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := tp.Tracer("isqlx_test")
+
+	exec := &fakeNamedExecer{results: []sql.Result{
+		fakeBatchResult{rowsAffected: 2},
+		fakeBatchResult{rowsAffected: 1},
+	}}
+
+	args := []interface{}{1, 2, 3}
+	res, err := namedExecBatchContext(context.Background(), exec, tracer, "mysql", nil, nil, "INSERT INTO t VALUES (:v)", args, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := res.RowsAffected()
+	if err != nil {
+		t.Fatalf("unexpected error from RowsAffected: %v", err)
+	}
+	if got != 3 {
+		t.Fatalf("expected the returned Result to report the aggregated 3 rows across both chunks, got %d", got)
+	}
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 ended span, got %d", len(ended))
+	}
+}
+
+func TestChunkBatchArgsSplitsIntoExpectedChunks(t *testing.T) {
+	chunks := chunkBatchArgs([]interface{}{1, 2, 3, 4, 5}, 2)
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[0]) != 2 || len(chunks[1]) != 2 || len(chunks[2]) != 1 {
+		t.Fatalf("unexpected chunk sizes: %v", chunks)
+	}
+}
+
+func TestChunkBatchArgsReturnsSingleChunkWhenBatchSizeNonPositive(t *testing.T) {
+	args := []interface{}{1, 2, 3}
+	chunks := chunkBatchArgs(args, 0)
+
+	if len(chunks) != 1 || len(chunks[0]) != len(args) {
+		t.Fatalf("expected a single chunk containing all args, got %v", chunks)
+	}
+}
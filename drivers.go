@@ -0,0 +1,33 @@
+package isqlx
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewPostgresDBX returns a DBX backed by db, assumed to be a PostgreSQL
+// connection. Because the driver is threaded through to every query, named
+// queries are rebound from `?`/`:name` placeholders to `$1`, `$2`, ... via
+// sqlx's bindvar machinery, and the db.system span attribute correctly
+// reports "postgres" instead of the MySQL default.
+func NewPostgresDBX(db *sql.DB, tracer trace.Tracer, opts ...Option) DBX {
+	d := &dbx{DB: sqlx.NewDb(db, "postgres"), driver: "postgres", tracer: tracer, stmtCache: newNamedStmtCache(defaultStatementCacheSize)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// NewSQLiteDBX returns a DBX backed by db, assumed to be a SQLite connection
+// opened through the sqlite3 driver.
+func NewSQLiteDBX(db *sql.DB, tracer trace.Tracer, opts ...Option) DBX {
+	d := &dbx{DB: sqlx.NewDb(db, "sqlite3"), driver: "sqlite3", tracer: tracer, stmtCache: newNamedStmtCache(defaultStatementCacheSize)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
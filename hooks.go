@@ -0,0 +1,33 @@
+package isqlx
+
+import "log"
+
+// AfterCommit registers fn to run once the transaction has committed
+// successfully.
+func (t *tx) AfterCommit(fn func()) {
+	t.afterCommit = append(t.afterCommit, fn)
+}
+
+// AfterRollback registers fn to run once the transaction has rolled back.
+func (t *tx) AfterRollback(fn func()) {
+	t.afterRollback = append(t.afterRollback, fn)
+}
+
+// runHooks invokes every hook in order, recovering from panics in
+// individual callbacks so that one bad hook doesn't prevent the rest from
+// running.
+func runHooks(hooks []func()) {
+	for _, fn := range hooks {
+		runHook(fn)
+	}
+}
+
+func runHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("recovered an error in transaction hook: %#v", r)
+		}
+	}()
+
+	fn()
+}
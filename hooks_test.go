@@ -0,0 +1,36 @@
+package isqlx
+
+import "testing"
+
+func TestRunHooksRunsEveryHookInOrder(t *testing.T) {
+	var order []int
+
+	runHooks([]func(){
+		func() { order = append(order, 1) },
+		func() { order = append(order, 2) },
+		func() { order = append(order, 3) },
+	})
+
+	want := []int{1, 2, 3}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestRunHooksRecoversFromPanicAndContinues(t *testing.T) {
+	var secondRan bool
+
+	runHooks([]func(){
+		func() { panic("boom") },
+		func() { secondRan = true },
+	})
+
+	if !secondRan {
+		t.Fatalf("expected the hook after the panicking one to still run")
+	}
+}
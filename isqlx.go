@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -24,7 +25,25 @@ type Querier interface {
 // DBX is an interface to make single queries without leveraging transactions.
 type DBX interface {
 	Querier
-	Begin(ctx context.Context) (TX, error)
+	Begin(ctx context.Context, opts *sql.TxOptions) (TX, error)
+
+	// RunInTransaction begins a transaction, invokes fn and commits if fn
+	// returns nil, rolling back on error or panic. It removes the need for
+	// callers to manually Begin/defer TxClose/Commit.
+	RunInTransaction(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) error
+
+	// Ping verifies that the underlying connection is still alive.
+	Ping(ctx context.Context) error
+
+	// NamedExecBatchContext runs query once per chunk of batchSize elements
+	// of args, relying on sqlx's slice-expanding NamedExecContext to turn
+	// each chunk into a single multi-row statement. This replaces hand-rolled
+	// strings.Repeat("(?,?,?),", n) loops for bulk inserts.
+	NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error)
+
+	// PrepareNamedContext returns a cached, server-side prepared statement
+	// for query, preparing and caching it on first use.
+	PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error)
 
 	// GetSQLX is a way to escape the abstraction when needed.
 	GetSQLX() *sqlx.DB
@@ -38,86 +57,187 @@ type TX interface {
 	Querier
 	Commit(ctx context.Context) error
 	TxClose(ctx context.Context)
+
+	// RunInTransaction runs fn within a nested scope of this transaction,
+	// implemented via a SAVEPOINT so that a failure in fn only unwinds the
+	// nested scope instead of the whole transaction. opts is accepted for
+	// interface symmetry with DBX.RunInTransaction, but savepoints don't
+	// support isolation levels, so it's ignored.
+	RunInTransaction(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) error
+
+	// AfterCommit registers fn to run once the transaction has committed
+	// successfully. Use it for side effects that must not happen if the
+	// write is later rolled back, such as publishing domain events.
+	AfterCommit(fn func())
+
+	// AfterRollback registers fn to run once the transaction has rolled
+	// back, whether because of an error or a panic.
+	AfterRollback(fn func())
+
+	// NamedExecBatchContext runs query once per chunk of batchSize elements
+	// of args, relying on sqlx's slice-expanding NamedExecContext to turn
+	// each chunk into a single multi-row statement.
+	NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error)
+
+	// PrepareNamedContext returns a statement prepared against this
+	// transaction's underlying DB statement (re-prepared via sqlx's
+	// tx.NamedStmt), and closed automatically on Commit/TxClose to match
+	// Go's stdlib semantics where tx-scoped statements die with the
+	// transaction.
+	PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error)
 }
 
 // TODO: if instead of taking the actual sql.DB we took the connection details,
 // these could be tracked in a per-span basis.
-func NewMySQLDBX(db *sql.DB, tracer trace.Tracer) DBX {
-	d := sqlx.NewDb(db, "mysql")
-	return &dbx{DB: d, driver: "mysql", tracer: tracer}
+func NewMySQLDBX(db *sql.DB, tracer trace.Tracer, opts ...Option) DBX {
+	d := &dbx{DB: sqlx.NewDb(db, "mysql"), driver: "mysql", tracer: tracer, stmtCache: newNamedStmtCache(defaultStatementCacheSize)}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 type dbx struct {
 	DB     *sqlx.DB
 	driver string
 	tracer trace.Tracer
+
+	// txDB, when set, is a separate connection pool that Begin draws from
+	// instead of DB. See NewMySQLDBXWithTxPool.
+	txDB *sqlx.DB
+
+	// commenter, when set, injects a sqlcommenter-style SQL comment carrying
+	// trace context into the queries run through the Querier methods and
+	// NamedExecBatchContext. See WithSQLCommenter.
+	commenter *sqlCommenter
+
+	// tracingOpts, when set, overrides the default statement/parameter
+	// capture policy. See WithTracingOptions.
+	tracingOpts *TracingOptions
+
+	// stmtCache holds server-side prepared statements keyed by query text.
+	// See PrepareNamedContext.
+	stmtCache *namedStmtCache
 }
 
 type tx struct {
-	TX     *sqlx.Tx
-	driver string
-	tracer trace.Tracer
+	TX          *sqlx.Tx
+	driver      string
+	tracer      trace.Tracer
+	commenter   *sqlCommenter
+	tracingOpts *TracingOptions
+
+	// db is the DBX this transaction was begun from, used by
+	// PrepareNamedContext to share its statement cache.
+	db *dbx
+
+	// txStmts accumulates the statements prepared via PrepareNamedContext on
+	// this transaction, closed when the transaction ends.
+	txStmts []*sqlx.NamedStmt
+
+	// savepointCount tracks how many nested RunInTransaction scopes have been
+	// opened on this transaction, so each one gets its own savepoint name.
+	savepointCount int
+
+	// afterCommit and afterRollback accumulate the callbacks registered via
+	// AfterCommit/AfterRollback, run once the transaction terminates.
+	afterCommit   []func()
+	afterRollback []func()
 }
 
 func (d *dbx) GetSQLX() *sqlx.DB {
 	return d.DB
 }
 
+func (d *dbx) Ping(ctx context.Context) error {
+	return d.DB.PingContext(ctx)
+}
+
 func (d *dbx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return getContext(ctx, d, d.tracer, d.driver, dest, query, args)
+	return getContext(ctx, d.DB, d.tracer, d.driver, d.commenter, d.tracingOpts, dest, query, args...)
 }
 
 func (d *dbx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return selectContext(ctx, d, d.tracer, d.driver, dest, query, args)
+	return selectContext(ctx, d.DB, d.tracer, d.driver, d.commenter, d.tracingOpts, dest, query, args...)
 }
 
 func (d *dbx) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
-	return namedExecContext(ctx, d, d.tracer, d.driver, query, arg)
+	return namedExecContext(ctx, d.DB, d.tracer, d.driver, d.commenter, d.tracingOpts, query, arg)
 }
 
-func (d *dbx) Begin(_ context.Context) (TX, error) {
-	t, err := d.DB.Beginx()
+func (d *dbx) Begin(ctx context.Context, opts *sql.TxOptions) (TX, error) {
+	pool := d.DB
+	if d.txDB != nil {
+		pool = d.txDB
+	}
+
+	t, err := pool.BeginTxx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &tx{TX: t, driver: d.driver, tracer: d.tracer}, nil
+	return &tx{TX: t, driver: d.driver, tracer: d.tracer, commenter: d.commenter, tracingOpts: d.tracingOpts, db: d}, nil
+}
+
+// RunInTransaction begins a transaction, invokes fn and commits on nil
+// return, rolling back on error or panic via TxClose.
+func (d *dbx) RunInTransaction(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) error {
+	t, err := d.Begin(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer t.TxClose(ctx)
+
+	if err := fn(t); err != nil {
+		return err
+	}
+
+	return t.Commit(ctx)
 }
 
 func (t *tx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return getContext(ctx, t, t.tracer, t.driver, dest, query, args...)
+	return getContext(ctx, t.TX, t.tracer, t.driver, t.commenter, t.tracingOpts, dest, query, args...)
 }
 
 func (t *tx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	return selectContext(ctx, t, t.tracer, t.driver, dest, query, args)
+	return selectContext(ctx, t.TX, t.tracer, t.driver, t.commenter, t.tracingOpts, dest, query, args...)
 }
 
 func (t *tx) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
-	return namedExecContext(ctx, t, t.tracer, t.driver, query, arg)
+	return namedExecContext(ctx, t.TX, t.tracer, t.driver, t.commenter, t.tracingOpts, query, arg)
 }
 
 func (t *tx) Commit(ctx context.Context) error {
-	_, span := newSpan(ctx, t.driver, "commit", t.tracer)
+	_, span := newSpan(ctx, t.driver, "commit", t.tracer, t.tracingOpts)
 	defer span.End()
 
 	err := t.TX.Commit()
 	if err != nil {
 		span.RecordError(err)
+		t.closeTxStmts()
+		return err
 	}
 
-	return err
+	t.closeTxStmts()
+	runHooks(t.afterCommit)
+
+	return nil
 }
 
 // TxClose makes sure the transaction gets rolled back. It should be run within
 // a `defer` statement so it can rollback transactions even in the case of
 // panics.
 func (t *tx) TxClose(ctx context.Context) {
-	_, span := newSpan(ctx, t.driver, "rollback", t.tracer)
+	_, span := newSpan(ctx, t.driver, "rollback", t.tracer, t.tracingOpts)
 	defer span.End()
 
 	if r := recover(); r != nil {
 		log.Printf("recovered an error in TxClose(): %#v", r)
-		_ = t.TX.Rollback()
+		t.closeTxStmts()
+		if err := t.TX.Rollback(); err == nil {
+			runHooks(t.afterRollback)
+		}
 		panic(r)
 	} else {
 		// Transaction leak failsafe:
@@ -125,17 +245,22 @@ func (t *tx) TxClose(ctx context.Context) {
 		// I don't check the errors here because the transaction might already
 		// have been committed/rolledback. If there's an issue with the database
 		// connection we'll catch it the next time that db handle gets used.
-		_ = t.TX.Rollback()
+		t.closeTxStmts()
+		if err := t.TX.Rollback(); err == nil {
+			runHooks(t.afterRollback)
+		}
 	}
 }
 
-func getContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, dest interface{}, query string, args ...interface{}) error {
-	ctx, span := newSpan(ctx, driver, query, tracer)
+func getContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, commenter *sqlCommenter, tracingOpts *TracingOptions, dest interface{}, query string, args ...interface{}) error {
+	query = sqlx.Rebind(sqlx.BindType(driver), query)
+
+	ctx, span := newSpan(ctx, driver, query, tracer, tracingOpts)
 	defer span.End()
 
 	span.addQueryParams(args)
 
-	err := q.GetContext(ctx, dest, query, args...)
+	err := q.GetContext(ctx, dest, commenter.inject(ctx, query), args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			span.addAffectedRowsAttribute(0)
@@ -147,13 +272,15 @@ func getContext(ctx context.Context, q Querier, tracer trace.Tracer, driver stri
 	return err
 }
 
-func selectContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, dest interface{}, query string, args ...interface{}) error {
-	ctx, span := newSpan(ctx, driver, query, tracer)
+func selectContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, commenter *sqlCommenter, tracingOpts *TracingOptions, dest interface{}, query string, args ...interface{}) error {
+	query = sqlx.Rebind(sqlx.BindType(driver), query)
+
+	ctx, span := newSpan(ctx, driver, query, tracer, tracingOpts)
 	defer span.End()
 
 	span.addQueryParams(args)
 
-	err := q.SelectContext(ctx, dest, query, args...)
+	err := q.SelectContext(ctx, dest, commenter.inject(ctx, query), args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			span.addAffectedRowsAttribute(0)
@@ -167,19 +294,19 @@ func selectContext(ctx context.Context, q Querier, tracer trace.Tracer, driver s
 	return err
 }
 
-func namedExecContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, query string, arg interface{}) (sql.Result, error) {
-	ctx, span := newSpan(ctx, driver, query, tracer)
+func namedExecContext(ctx context.Context, q Querier, tracer trace.Tracer, driver string, commenter *sqlCommenter, tracingOpts *TracingOptions, query string, arg interface{}) (sql.Result, error) {
+	ctx, span := newSpan(ctx, driver, query, tracer, tracingOpts)
 	defer span.End()
 
 	// I'm not sure if there are more use cases other than a map, but to be safe,
 	// I decided to wrap it in a conditional. As we find new, let's just add them here though.
 	if m, ok := arg.(map[string]interface{}); ok {
 		for k, v := range m {
-			span.addQueryParamAttribute(k, fmt.Sprint(v))
+			span.recordQueryParam(k, v)
 		}
 	}
 
-	r, err := q.NamedExecContext(ctx, query, arg)
+	r, err := q.NamedExecContext(ctx, commenter.inject(ctx, query), arg)
 	if err != nil {
 		if err != sql.ErrNoRows {
 			span.RecordError(err)
@@ -191,25 +318,61 @@ func namedExecContext(ctx context.Context, q Querier, tracer trace.Tracer, drive
 	return r, err
 }
 
-func parseQueryOperation(query string) string {
+func parseQueryOperation(driver, query string) string {
 	query = strings.ToLower(query)
 	if strings.HasPrefix(query, "update") { // nolint: gocritic
-		return "update"
+		return withReturning(driver, query, "update")
 	} else if strings.HasPrefix(query, "select") {
 		return "select"
 	} else if strings.HasPrefix(query, "insert") {
-		return "insert"
+		return withReturning(driver, query, "insert")
 	} else if strings.HasPrefix(query, "delete") {
-		return "delete"
+		return withReturning(driver, query, "delete")
 	} else if strings.HasPrefix(query, "commit") {
 		return "commit"
+	} else if strings.HasPrefix(query, "savepoint") {
+		return "savepoint"
+	} else if strings.HasPrefix(query, "release") {
+		return "release"
+	} else if strings.HasPrefix(query, "rollback to") {
+		return "rollback_to"
 	} else if strings.HasPrefix(query, "rollback") {
 		return "rollback"
 	}
 
+	// WITH (CTEs) and MERGE are Postgres-specific statement forms; gate them
+	// on the driver so MySQL/SQLite queries starting with those words (rare,
+	// but not impossible as identifiers) keep falling through to "unknown".
+	if driver == "postgres" {
+		if strings.HasPrefix(query, "with") {
+			return "cte"
+		} else if strings.HasPrefix(query, "merge") {
+			return "merge"
+		}
+	}
+
 	return "unknown"
 }
 
+// withReturning appends a "_returning" suffix to op when query carries a
+// trailing RETURNING clause, a Postgres-specific extension to INSERT/UPDATE/
+// DELETE. It's gated on the driver for the same reason as the WITH/MERGE
+// handling above: MySQL/SQLite don't support RETURNING, so a bare word match
+// there would misclassify a column or alias named "returning".
+func withReturning(driver, query, op string) string {
+	if driver != "postgres" {
+		return op
+	}
+
+	for _, word := range strings.Fields(query) {
+		if word == "returning" {
+			return op + "_returning"
+		}
+	}
+
+	return op
+}
+
 // getReturnedRows extracts the amount of rows returned from dest assuming that it's
 // the result of a database operation.
 // @see https://goplay.tools/snippet/oKaFkTexWBk
@@ -230,27 +393,58 @@ func getReturnedRows(dest interface{}) int {
 // functions for adding attributes to the trace.
 type customSpan struct {
 	trace.Span
+	opts *TracingOptions
+}
+
+// instanceRoleKey carries the role ("primary"/"replica") of the node a query
+// is being routed to, so newSpan can annotate the span without every caller
+// having to thread it through explicitly. See withInstanceRole.
+type instanceRoleKey struct{}
+
+// withInstanceRole tags ctx with the database instance role a query is about
+// to be executed against, so the resulting span carries a db.instance.role
+// attribute alongside db.system.
+func withInstanceRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, instanceRoleKey{}, role)
 }
 
-func newSpan(ctx context.Context, driver, query string, tracer trace.Tracer) (context.Context, *customSpan) {
-	op := parseQueryOperation(query)
+func newSpan(ctx context.Context, driver, query string, tracer trace.Tracer, tracingOpts *TracingOptions) (context.Context, *customSpan) {
+	op := parseQueryOperation(driver, query)
 
 	ctx, span := tracer.Start(ctx, fmt.Sprintf("%s.%s", driver, op))
-	defer span.End()
 
 	span.SetAttributes(
 		attribute.String("db.system", driver),
 		attribute.String("db.operation", op),
-		attribute.String("db.statement", query),
+		attribute.String("db.statement", renderStatement(tracingOpts, query)),
 	)
 
-	return ctx, &customSpan{span}
+	if role, ok := ctx.Value(instanceRoleKey{}).(string); ok {
+		span.SetAttributes(attribute.String("db.instance.role", role))
+	}
+
+	cs := &customSpan{Span: span, opts: tracingOpts}
+	ctx = trace.ContextWithSpan(ctx, cs)
+
+	if tracingOpts != nil && tracingOpts.SpanHook != nil {
+		tracingOpts.SpanHook(ctx)
+	}
+
+	return ctx, cs
+}
+
+// SpanFromQueryContext returns the span of the query currently executing
+// against ctx, so middleware registered via WithSpanHook can attach its own
+// attributes to it. If ctx was not obtained from inside a Querier method,
+// this is whatever span ctx already carries, or a no-op span if it carries
+// none.
+func SpanFromQueryContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
 }
 
 func (s *customSpan) addQueryParams(args ...interface{}) {
-	for i := range args {
-		v := fmt.Sprint(args[i])
-		s.addQueryParams(i, v)
+	for i, v := range args {
+		s.recordQueryParam(strconv.Itoa(i), v)
 	}
 }
 
@@ -258,6 +452,23 @@ func (s *customSpan) addAffectedRowsAttribute(n int64) {
 	s.SetAttributes(attribute.Int64("db.result.returned_rows", n))
 }
 
-func (s *customSpan) addQueryParamAttribute(k, v string) {
-	s.SetAttributes(attribute.String(fmt.Sprintf("db.statement.param_%s", k), v))
+// recordQueryParam records a single bound parameter as a db.statement.param_*
+// attribute, honoring the span's capture/redaction policy: parameters are
+// skipped entirely when CaptureParameters is false (the default is true, for
+// backwards compatibility), and passed through ParameterRedactor when set.
+func (s *customSpan) recordQueryParam(k string, v interface{}) {
+	if s.opts != nil && !s.opts.CaptureParameters {
+		return
+	}
+
+	formatted := fmt.Sprint(v)
+	if s.opts != nil && s.opts.ParameterRedactor != nil {
+		if redacted, ok := s.opts.ParameterRedactor(k, v); ok {
+			formatted = redacted
+		} else {
+			return
+		}
+	}
+
+	s.SetAttributes(attribute.String(fmt.Sprintf("db.statement.param_%s", k), formatted))
 }
@@ -0,0 +1,164 @@
+package isqlx
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultStatementCacheSize is the number of prepared statements a DBX keeps
+// open per underlying connection pool before evicting the least recently
+// used one.
+const defaultStatementCacheSize = 100
+
+// NamedStmt is a prepared statement returned by PrepareNamedContext. It
+// matches the subset of *sqlx.NamedStmt's method set that callers need, so
+// the real *sqlx.NamedStmt can be returned directly without a wrapper.
+type NamedStmt interface {
+	GetContext(ctx context.Context, dest interface{}, arg interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, arg interface{}) error
+	ExecContext(ctx context.Context, arg interface{}) (sql.Result, error)
+	Close() error
+}
+
+type namedStmtCacheEntry struct {
+	query string
+	stmt  *sqlx.NamedStmt
+}
+
+// pendingPrepare coalesces concurrent callers preparing the same query text:
+// the first caller in does the real prepare, everyone else blocks on done
+// and shares its result instead of each preparing (and potentially closing
+// one another's statement) independently.
+type pendingPrepare struct {
+	done chan struct{}
+	stmt *sqlx.NamedStmt
+	err  error
+}
+
+// namedStmtCache is an LRU cache of prepared statements keyed by query text.
+// Entries evicted for capacity are closed; it is the caller's responsibility
+// to close whatever remains when the owning DBX is torn down.
+type namedStmtCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	pending  map[string]*pendingPrepare
+}
+
+func newNamedStmtCache(capacity int) *namedStmtCache {
+	return &namedStmtCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		pending:  make(map[string]*pendingPrepare),
+	}
+}
+
+// insertLocked adds stmt under query, evicting (and closing) the least
+// recently used entry if that pushes the cache over capacity. c.mu must
+// already be held.
+func (c *namedStmtCache) insertLocked(query string, stmt *sqlx.NamedStmt) {
+	el := c.ll.PushFront(&namedStmtCacheEntry{query: query, stmt: stmt})
+	c.items[query] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			entry := oldest.Value.(*namedStmtCacheEntry)
+			delete(c.items, entry.query)
+			_ = entry.stmt.Close()
+		}
+	}
+}
+
+// getOrPrepare returns the cached statement for query, coalescing concurrent
+// misses onto a single call to prepare so that racing callers share one
+// statement instead of each preparing (and potentially closing) their own.
+func (c *namedStmtCache) getOrPrepare(query string, prepare func() (*sqlx.NamedStmt, error)) (stmt *sqlx.NamedStmt, cached bool, err error) {
+	c.mu.Lock()
+
+	if el, ok := c.items[query]; ok {
+		c.ll.MoveToFront(el)
+		stmt := el.Value.(*namedStmtCacheEntry).stmt
+		c.mu.Unlock()
+		return stmt, true, nil
+	}
+
+	if p, ok := c.pending[query]; ok {
+		c.mu.Unlock()
+		<-p.done
+		return p.stmt, true, p.err
+	}
+
+	p := &pendingPrepare{done: make(chan struct{})}
+	c.pending[query] = p
+	c.mu.Unlock()
+
+	p.stmt, p.err = prepare()
+
+	c.mu.Lock()
+	delete(c.pending, query)
+	if p.err == nil {
+		c.insertLocked(query, p.stmt)
+	}
+	c.mu.Unlock()
+
+	close(p.done)
+
+	return p.stmt, false, p.err
+}
+
+// PrepareNamedContext returns a cached, server-side prepared statement for
+// query, preparing and caching it against d's connection pool on first use.
+// Concurrent calls for the same query coalesce onto a single prepare.
+func (d *dbx) PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error) {
+	ctx, span := newSpan(ctx, d.driver, query, d.tracer, d.tracingOpts)
+	defer span.End()
+
+	stmt, cached, err := d.stmtCache.getOrPrepare(query, func() (*sqlx.NamedStmt, error) {
+		return d.DB.PrepareNamedContext(ctx, query)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Bool("db.statement.cached", cached))
+
+	return stmt, nil
+}
+
+// PrepareNamedContext returns a statement prepared against this
+// transaction: it reuses (and populates, on first use) the owning DBX's
+// statement cache for the underlying DB-level statement, then adapts it to
+// the transaction via sqlx's tx.NamedStmt. The transaction-scoped statement
+// is tracked on t and closed automatically by Commit/TxClose, matching Go's
+// stdlib semantics where tx-scoped statements die with the transaction.
+func (t *tx) PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error) {
+	dbStmt, err := t.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	txStmt := t.TX.NamedStmt(dbStmt.(*sqlx.NamedStmt))
+	t.txStmts = append(t.txStmts, txStmt)
+
+	return txStmt, nil
+}
+
+// closeTxStmts closes every statement prepared via PrepareNamedContext on
+// this transaction. It is called from both Commit and TxClose so tx-scoped
+// statements are released regardless of outcome.
+func (t *tx) closeTxStmts() {
+	for _, stmt := range t.txStmts {
+		_ = stmt.Close()
+	}
+	t.txStmts = nil
+}
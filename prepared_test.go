@@ -0,0 +1,85 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func newTestSQLiteDBX(t *testing.T) DBX {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open sqlite3: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tracer := sdktrace.NewTracerProvider().Tracer("isqlx_test")
+	return NewSQLiteDBX(db, tracer)
+}
+
+// TestPrepareNamedContextCoalescesConcurrentPreparesForSameQuery is a
+// regression test for a race where concurrent PrepareNamedContext calls for
+// the same, not-yet-cached query each prepared their own statement, and a
+// later cache insert could close the statement an earlier caller was still holding,
+// leaving it unusable ("sql: statement is closed").
+func TestPrepareNamedContextCoalescesConcurrentPreparesForSameQuery(t *testing.T) {
+	d := newTestSQLiteDBX(t)
+
+	const query = "INSERT INTO widgets (name) VALUES (:name)"
+	const goroutines = 20
+
+	var wg sync.WaitGroup
+	errs := make([]error, goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			stmt, err := d.PrepareNamedContext(context.Background(), query)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			_, errs[i] = stmt.ExecContext(context.Background(), map[string]interface{}{"name": "widget"})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("goroutine %d: unexpected error (want every concurrent caller to share a usable statement): %v", i, err)
+		}
+	}
+}
+
+func TestPrepareNamedContextReusesCachedStatement(t *testing.T) {
+	d := newTestSQLiteDBX(t)
+
+	const query = "INSERT INTO widgets (name) VALUES (:name)"
+
+	first, err := d.PrepareNamedContext(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := d.PrepareNamedContext(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected the second call to return the cached statement instead of preparing a new one")
+	}
+}
@@ -0,0 +1,228 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LoadBalancePolicy selects how NewResolverDBX distributes reads across its
+// replicas.
+type LoadBalancePolicy int
+
+const (
+	// RoundRobin cycles through healthy replicas in order.
+	RoundRobin LoadBalancePolicy = iota
+
+	// Random picks a healthy replica uniformly at random.
+	Random
+)
+
+// HealthChecker is implemented by DBX instances that track the liveness of
+// the nodes they route queries to, such as the one returned by
+// NewResolverDBX.
+type HealthChecker interface {
+	// HealthCheck pings every tracked replica and updates its availability.
+	// Unhealthy replicas are skipped by subsequent routing decisions until
+	// a later HealthCheck call marks them healthy again.
+	HealthCheck(ctx context.Context) error
+}
+
+type replicaNode struct {
+	dbx     *dbx
+	healthy atomic.Bool
+}
+
+// resolverDBX is a DBX that splits reads and writes across a primary and a
+// set of replicas: NamedExecContext and anything inside a TX always goes to
+// the primary, while GetContext/SelectContext are load-balanced across
+// replicas unless the query demands primary consistency.
+type resolverDBX struct {
+	primary  *dbx
+	replicas []*replicaNode
+	policy   LoadBalancePolicy
+	counter  uint64
+}
+
+// NewResolverDBX returns a DBX that routes SelectContext/GetContext to a
+// replica (chosen per policy) while NamedExecContext and any query inside a
+// TX always hit primary. Queries that require primary consistency — SELECT
+// ... FOR UPDATE and CTEs that write — are also forced to primary even when
+// issued through SelectContext/GetContext.
+func NewResolverDBX(primary *sql.DB, replicas []*sql.DB, tracer trace.Tracer, policy LoadBalancePolicy, opts ...Option) DBX {
+	r := &resolverDBX{
+		primary: &dbx{DB: sqlx.NewDb(primary, "mysql"), driver: "mysql", tracer: tracer, stmtCache: newNamedStmtCache(defaultStatementCacheSize)},
+		policy:  policy,
+	}
+	for _, opt := range opts {
+		opt(r.primary)
+	}
+
+	for _, replica := range replicas {
+		node := &replicaNode{dbx: &dbx{DB: sqlx.NewDb(replica, "mysql"), driver: "mysql", tracer: tracer, stmtCache: newNamedStmtCache(defaultStatementCacheSize)}}
+		for _, opt := range opts {
+			opt(node.dbx)
+		}
+		node.healthy.Store(true)
+		r.replicas = append(r.replicas, node)
+	}
+
+	return r
+}
+
+func (r *resolverDBX) GetSQLX() *sqlx.DB {
+	return r.primary.DB
+}
+
+func (r *resolverDBX) Ping(ctx context.Context) error {
+	return r.primary.Ping(ctx)
+}
+
+func (r *resolverDBX) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	node, role := r.pickNode(query)
+	return node.GetContext(withInstanceRole(ctx, role), dest, query, args...)
+}
+
+func (r *resolverDBX) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	node, role := r.pickNode(query)
+	return node.SelectContext(withInstanceRole(ctx, role), dest, query, args...)
+}
+
+func (r *resolverDBX) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return r.primary.NamedExecContext(withInstanceRole(ctx, "primary"), query, arg)
+}
+
+func (r *resolverDBX) Begin(ctx context.Context, opts *sql.TxOptions) (TX, error) {
+	t, err := r.primary.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resolverTX{TX: t}, nil
+}
+
+func (r *resolverDBX) RunInTransaction(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) error {
+	return r.primary.RunInTransaction(ctx, opts, func(t TX) error {
+		return fn(&resolverTX{TX: t})
+	})
+}
+
+func (r *resolverDBX) NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error) {
+	return r.primary.NamedExecBatchContext(withInstanceRole(ctx, "primary"), query, args, batchSize)
+}
+
+func (r *resolverDBX) PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error) {
+	return r.primary.PrepareNamedContext(withInstanceRole(ctx, "primary"), query)
+}
+
+// HealthCheck pings every replica and marks it healthy or unhealthy, so
+// pickNode can skip dead nodes and fail over to the remaining ones (or to
+// primary, if none are left).
+func (r *resolverDBX) HealthCheck(ctx context.Context) error {
+	var firstErr error
+	for _, node := range r.replicas {
+		err := node.dbx.DB.PingContext(ctx)
+		node.healthy.Store(err == nil)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// pickNode decides which node should serve query, returning it alongside the
+// db.instance.role value that should be recorded on its span.
+func (r *resolverDBX) pickNode(query string) (Querier, string) {
+	if requiresPrimary(r.primary.driver, query) {
+		return r.primary, "primary"
+	}
+
+	if node := r.nextHealthyReplica(); node != nil {
+		return node.dbx, "replica"
+	}
+
+	return r.primary, "primary"
+}
+
+func (r *resolverDBX) nextHealthyReplica() *replicaNode {
+	healthy := make([]*replicaNode, 0, len(r.replicas))
+	for _, node := range r.replicas {
+		if node.healthy.Load() {
+			healthy = append(healthy, node)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch r.policy {
+	case Random:
+		return healthy[rand.Intn(len(healthy))]
+	default: // RoundRobin
+		i := atomic.AddUint64(&r.counter, 1)
+		return healthy[int(i)%len(healthy)]
+	}
+}
+
+// requiresPrimary reports whether query must be routed to primary even when
+// issued through SelectContext/GetContext: locking reads (SELECT ... FOR
+// UPDATE) and CTEs that write (WITH ... INSERT/UPDATE/DELETE) would silently
+// read stale or inconsistent data from a replica otherwise. Any other query
+// that parseQueryOperation does not classify as "select", including a
+// read-only CTE, is routed to primary as well.
+func requiresPrimary(driver, query string) bool {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	if strings.Contains(q, "for update") {
+		return true
+	}
+
+	if strings.HasPrefix(q, "with") {
+		for _, kw := range []string{"insert", "update", "delete"} {
+			if strings.Contains(q, kw) {
+				return true
+			}
+		}
+	}
+
+	return parseQueryOperation(driver, query) != "select"
+}
+
+// resolverTX tags every query run against the wrapped TX with the primary
+// instance role, since transactions always execute against primary.
+type resolverTX struct {
+	TX
+}
+
+func (t *resolverTX) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.TX.GetContext(withInstanceRole(ctx, "primary"), dest, query, args...)
+}
+
+func (t *resolverTX) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.TX.SelectContext(withInstanceRole(ctx, "primary"), dest, query, args...)
+}
+
+func (t *resolverTX) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return t.TX.NamedExecContext(withInstanceRole(ctx, "primary"), query, arg)
+}
+
+func (t *resolverTX) RunInTransaction(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) error {
+	return t.TX.RunInTransaction(ctx, opts, func(nested TX) error {
+		return fn(&resolverTX{TX: nested})
+	})
+}
+
+func (t *resolverTX) NamedExecBatchContext(ctx context.Context, query string, args []interface{}, batchSize int) (sql.Result, error) {
+	return t.TX.NamedExecBatchContext(withInstanceRole(ctx, "primary"), query, args, batchSize)
+}
+
+func (t *resolverTX) PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error) {
+	return t.TX.PrepareNamedContext(withInstanceRole(ctx, "primary"), query)
+}
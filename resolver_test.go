@@ -0,0 +1,70 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequiresPrimaryRoutesLockingAndWritingStatementsToPrimary(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"plain select", "SELECT * FROM widgets", false},
+		{"select for update", "SELECT * FROM widgets WHERE id = ? FOR UPDATE", true},
+		{"writing cte", "WITH moved AS (INSERT INTO widgets (name) VALUES ('x') RETURNING id) SELECT * FROM moved", true},
+		{"insert", "INSERT INTO widgets (name) VALUES ('x')", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := requiresPrimary("postgres", c.query); got != c.want {
+				t.Fatalf("requiresPrimary(%q) = %v, want %v", c.query, got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolverDBXRunInTransactionTagsPrimaryRoleOnQueries(t *testing.T) {
+	primaryDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	t.Cleanup(func() { _ = primaryDB.Close() })
+
+	if _, err := primaryDB.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	r := NewResolverDBX(primaryDB, nil, tp.Tracer("isqlx_test"), RoundRobin)
+
+	err = r.RunInTransaction(context.Background(), nil, func(tx TX) error {
+		var n int
+		return tx.GetContext(context.Background(), &n, "SELECT COUNT(*) FROM widgets")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sawPrimaryRoleOnSelect bool
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.instance.role" && attr.Value.AsString() == "primary" {
+				sawPrimaryRoleOnSelect = true
+			}
+		}
+	}
+
+	if !sawPrimaryRoleOnSelect {
+		t.Fatalf("expected the query issued inside RunInTransaction to be tagged db.instance.role=primary, like Begin's TX already is")
+	}
+}
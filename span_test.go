@@ -0,0 +1,67 @@
+package isqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestNewSpanKeepsSpanOpenUntilCallerEndsIt is a regression test for a bug
+// where newSpan ended its span internally before returning it, so anything
+// recorded on it afterwards — notably the RecordError/SetAttributes calls
+// that getContext/selectContext/namedExecContext make once the query
+// actually finishes — was silently dropped.
+func TestNewSpanKeepsSpanOpenUntilCallerEndsIt(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := tp.Tracer("isqlx_test")
+
+	ctx, span := newSpan(context.Background(), "mysql", "SELECT 1", tracer, nil)
+
+	if got := SpanFromQueryContext(ctx); !got.SpanContext().IsValid() {
+		t.Fatalf("expected SpanFromQueryContext to return the in-flight span")
+	}
+
+	wantErr := errors.New("boom")
+	span.RecordError(wantErr)
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 ended span, got %d", len(ended))
+	}
+
+	var foundException bool
+	for _, event := range ended[0].Events() {
+		if event.Name == "exception" {
+			foundException = true
+		}
+	}
+	if !foundException {
+		t.Fatalf("expected the error recorded after newSpan returned to appear on the finished span, got events: %v", ended[0].Events())
+	}
+}
+
+func TestSpanHookCanAnnotateInFlightSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := tp.Tracer("isqlx_test")
+
+	var hookCalled bool
+	opts := &TracingOptions{
+		SpanHook: func(ctx context.Context) {
+			hookCalled = true
+			SpanFromQueryContext(ctx)
+		},
+	}
+
+	_, span := newSpan(context.Background(), "mysql", "SELECT 1", tracer, opts)
+	span.End()
+
+	if !hookCalled {
+		t.Fatalf("expected SpanHook to run while the query span is in flight")
+	}
+}
@@ -0,0 +1,136 @@
+package isqlx
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures a DBX at construction time. See WithSQLCommenter.
+type Option func(*dbx)
+
+// CommenterKey selects an additional field that WithSQLCommenter pulls from
+// the query context and emits in the sqlcommenter comment, alongside the
+// application name, which is always included once a commenter is configured,
+// and the W3C traceparent, which is included whenever the query context
+// carries a valid span context.
+type CommenterKey int
+
+const (
+	// CommenterController emits the value set via WithController.
+	CommenterController CommenterKey = iota
+
+	// CommenterRoute emits the value set via WithRoute.
+	CommenterRoute
+
+	// CommenterFramework emits the value set via WithFramework.
+	CommenterFramework
+)
+
+// WithSQLCommenter opts a DBX into sqlcommenter-style trace propagation: a
+// SQL comment carrying the current OpenTelemetry trace context (and,
+// optionally, request metadata) is appended to every query run through
+// GetContext, SelectContext, NamedExecContext and NamedExecBatchContext, so
+// downstream tools (slow-query logs, DB proxies, pganalyze-style tools) can
+// correlate a database statement back to the originating request. Statements
+// prepared via PrepareNamedContext and the SAVEPOINT statements of nested
+// RunInTransaction calls carry no comment.
+func WithSQLCommenter(appName string, keys ...CommenterKey) Option {
+	enabled := make(map[CommenterKey]bool, len(keys))
+	for _, k := range keys {
+		enabled[k] = true
+	}
+
+	return func(d *dbx) {
+		d.commenter = &sqlCommenter{appName: appName, keys: enabled}
+	}
+}
+
+type sqlCommenter struct {
+	appName string
+	keys    map[CommenterKey]bool
+}
+
+type commenterCtxKey int
+
+const (
+	controllerCtxKey commenterCtxKey = iota
+	routeCtxKey
+	frameworkCtxKey
+)
+
+// WithController tags ctx with the controller/handler name to emit when
+// CommenterController is enabled.
+func WithController(ctx context.Context, controller string) context.Context {
+	return context.WithValue(ctx, controllerCtxKey, controller)
+}
+
+// WithRoute tags ctx with the route to emit when CommenterRoute is enabled.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeCtxKey, route)
+}
+
+// WithFramework tags ctx with the framework name to emit when
+// CommenterFramework is enabled.
+func WithFramework(ctx context.Context, framework string) context.Context {
+	return context.WithValue(ctx, frameworkCtxKey, framework)
+}
+
+// inject appends a sqlcommenter-style comment to query carrying the trace
+// context (and any enabled request metadata found on ctx). It's safe to call
+// on a nil *sqlCommenter, in which case query is returned unchanged.
+func (c *sqlCommenter) inject(ctx context.Context, query string) string {
+	if c == nil {
+		return query
+	}
+
+	comment := c.comment(ctx)
+	if comment == "" {
+		return query
+	}
+
+	return query + " " + comment
+}
+
+func (c *sqlCommenter) comment(ctx context.Context) string {
+	kvs := map[string]string{"application": c.appName}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		kvs["traceparent"] = fmt.Sprintf("00-%s-%s-%02x", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+	}
+
+	if c.keys[CommenterController] {
+		if v, ok := ctx.Value(controllerCtxKey).(string); ok && v != "" {
+			kvs["controller"] = v
+		}
+	}
+
+	if c.keys[CommenterRoute] {
+		if v, ok := ctx.Value(routeCtxKey).(string); ok && v != "" {
+			kvs["route"] = v
+		}
+	}
+
+	if c.keys[CommenterFramework] {
+		if v, ok := ctx.Value(frameworkCtxKey).(string); ok && v != "" {
+			kvs["framework"] = v
+		}
+	}
+
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", url.QueryEscape(k), url.QueryEscape(kvs[k])))
+	}
+
+	return fmt.Sprintf("/*%s*/", strings.Join(pairs, ","))
+}
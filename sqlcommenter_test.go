@@ -0,0 +1,45 @@
+package isqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLCommenterInjectIsNoopWhenNil(t *testing.T) {
+	var c *sqlCommenter
+
+	got := c.inject(context.Background(), "SELECT 1")
+	if got != "SELECT 1" {
+		t.Fatalf("expected query to pass through unchanged, got %q", got)
+	}
+}
+
+func TestSQLCommenterInjectAppendsEnabledKeysInSortedOrder(t *testing.T) {
+	c := &sqlCommenter{
+		appName: "billing",
+		keys: map[CommenterKey]bool{
+			CommenterController: true,
+			CommenterRoute:      true,
+		},
+	}
+
+	ctx := WithRoute(WithController(context.Background(), "InvoicesController"), "/invoices")
+	got := c.inject(ctx, "SELECT 1")
+
+	want := "SELECT 1 /*application='billing',controller='InvoicesController',route='%2Finvoices'*/"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSQLCommenterInjectSkipsKeysNotEnabled(t *testing.T) {
+	c := &sqlCommenter{appName: "billing", keys: map[CommenterKey]bool{}}
+
+	ctx := WithController(context.Background(), "InvoicesController")
+	got := c.inject(ctx, "SELECT 1")
+
+	want := "SELECT 1 /*application='billing'*/"
+	if got != want {
+		t.Fatalf("expected controller to be omitted since CommenterController wasn't enabled, got %q", got)
+	}
+}
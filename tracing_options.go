@@ -0,0 +1,96 @@
+package isqlx
+
+import (
+	"context"
+	"regexp"
+)
+
+// TracingOptions controls how much of a query's statement and parameters end
+// up on its span. Callers that never configure it via WithTracingOptions keep
+// the library's original, permissive behaviour: every parameter is captured
+// and the statement is recorded unredacted and untruncated. Opting in via
+// WithTracingOptions switches to the safe-by-default zero value of this
+// struct — no parameters captured, no redaction, no truncation — so callers
+// must explicitly enable the pieces they want.
+type TracingOptions struct {
+	// CaptureParameters, when true, records each bound parameter as a
+	// db.statement.param_* attribute.
+	CaptureParameters bool
+
+	// MaxStatementLength truncates the db.statement attribute to this many
+	// bytes, appending an ellipsis. Zero means no limit.
+	MaxStatementLength int
+
+	// Redactor rewrites the query text before it's recorded as db.statement.
+	// If nil, the query is recorded as-is. See DefaultRedactor.
+	Redactor func(query string) string
+
+	// ParameterRedactor rewrites a parameter before it's recorded. Returning
+	// false drops the parameter entirely instead of recording it.
+	ParameterRedactor func(name string, value any) (string, bool)
+
+	// SpanHook, if set, runs immediately after a query span starts, with the
+	// span reachable via SpanFromQueryContext(ctx). It lets middleware that
+	// knows about the current request — tenant id, feature flags, and so on
+	// — attach its own attributes to the in-flight span without this
+	// package needing to know about any of it.
+	SpanHook func(ctx context.Context)
+}
+
+// WithTracingOptions opts a DBX into the given statement/parameter capture
+// policy, in place of the permissive defaults.
+func WithTracingOptions(opts TracingOptions) Option {
+	return func(d *dbx) {
+		d.tracingOpts = &opts
+	}
+}
+
+// TracerProviderOption is an Option for customizing how query spans are
+// started and enriched, as opposed to TracingOptions, which governs how
+// much of a finished query ends up on its span.
+type TracerProviderOption = Option
+
+// WithSpanHook is a TracerProviderOption that registers fn as the DBX's
+// TracingOptions.SpanHook, without requiring callers to construct a full
+// TracingOptions just to observe in-flight spans.
+func WithSpanHook(fn func(ctx context.Context)) TracerProviderOption {
+	return func(d *dbx) {
+		if d.tracingOpts == nil {
+			d.tracingOpts = &TracingOptions{CaptureParameters: true}
+		}
+		d.tracingOpts.SpanHook = fn
+	}
+}
+
+var (
+	stringLiteralPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteralPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+)
+
+// DefaultRedactor replaces string and numeric literals in query with `?`, so
+// that, for example, `SELECT * FROM u WHERE email='a@b'` becomes
+// `SELECT * FROM u WHERE email=?`.
+func DefaultRedactor(query string) string {
+	query = stringLiteralPattern.ReplaceAllString(query, "?")
+	return numberLiteralPattern.ReplaceAllString(query, "?")
+}
+
+// renderStatement applies opts' Redactor and MaxStatementLength to query, if
+// configured, for use in the db.statement span attribute only; it must never
+// be used for the query actually sent to the database.
+func renderStatement(opts *TracingOptions, query string) string {
+	if opts == nil {
+		return query
+	}
+
+	stmt := query
+	if opts.Redactor != nil {
+		stmt = opts.Redactor(stmt)
+	}
+
+	if opts.MaxStatementLength > 0 && len(stmt) > opts.MaxStatementLength {
+		stmt = stmt[:opts.MaxStatementLength] + "..."
+	}
+
+	return stmt
+}
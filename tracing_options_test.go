@@ -0,0 +1,45 @@
+package isqlx
+
+import "testing"
+
+func TestDefaultRedactorReplacesStringAndNumericLiterals(t *testing.T) {
+	got := DefaultRedactor(`SELECT * FROM users WHERE email='a@b.com' AND age=30`)
+	want := `SELECT * FROM users WHERE email=? AND age=?`
+
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderStatementReturnsQueryUnchangedWhenOptsNil(t *testing.T) {
+	query := `SELECT * FROM users WHERE email='a@b.com'`
+
+	got := renderStatement(nil, query)
+	if got != query {
+		t.Fatalf("expected permissive default to leave query untouched, got %q", got)
+	}
+}
+
+func TestRenderStatementAppliesRedactorAndTruncation(t *testing.T) {
+	opts := &TracingOptions{
+		Redactor:           DefaultRedactor,
+		MaxStatementLength: 10,
+	}
+
+	got := renderStatement(opts, `SELECT * FROM users WHERE email='a@b.com'`)
+	want := "SELECT * F..."
+
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderStatementSkipsTruncationWhenUnderLimit(t *testing.T) {
+	opts := &TracingOptions{MaxStatementLength: 1000}
+
+	query := "SELECT 1"
+	got := renderStatement(opts, query)
+	if got != query {
+		t.Fatalf("got %q, want %q", got, query)
+	}
+}
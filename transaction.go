@@ -0,0 +1,78 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// RunInTransaction runs fn within a nested scope of t, implemented via a
+// SAVEPOINT. This allows callers to compose RunInTransaction calls without
+// opening a new connection: a failure in fn rolls back only to the savepoint,
+// leaving the outer transaction free to continue or retry.
+func (t *tx) RunInTransaction(ctx context.Context, _ *sql.TxOptions, fn func(TX) error) (err error) {
+	t.savepointCount++
+	name := fmt.Sprintf("sp_%d", t.savepointCount)
+
+	if err := t.savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = t.rollbackToSavepoint(ctx, name)
+			panic(r)
+		}
+	}()
+
+	if err := fn(t); err != nil {
+		if rbErr := t.rollbackToSavepoint(ctx, name); rbErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint also failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return t.releaseSavepoint(ctx, name)
+}
+
+func (t *tx) savepoint(ctx context.Context, name string) error {
+	query := fmt.Sprintf("SAVEPOINT %s", name)
+
+	ctx, span := newSpan(ctx, t.driver, query, t.tracer, t.tracingOpts)
+	defer span.End()
+
+	_, err := t.TX.ExecContext(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
+
+func (t *tx) releaseSavepoint(ctx context.Context, name string) error {
+	query := fmt.Sprintf("RELEASE SAVEPOINT %s", name)
+
+	ctx, span := newSpan(ctx, t.driver, query, t.tracer, t.tracingOpts)
+	defer span.End()
+
+	_, err := t.TX.ExecContext(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
+
+func (t *tx) rollbackToSavepoint(ctx context.Context, name string) error {
+	query := fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", name)
+
+	ctx, span := newSpan(ctx, t.driver, query, t.tracer, t.tracingOpts)
+	defer span.End()
+
+	_, err := t.TX.ExecContext(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,75 @@
+package isqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func countWidgets(t *testing.T, tx TX) int {
+	t.Helper()
+
+	var n int
+	if err := tx.GetContext(context.Background(), &n, "SELECT COUNT(*) FROM widgets"); err != nil {
+		t.Fatalf("unexpected error counting widgets: %v", err)
+	}
+	return n
+}
+
+func TestRunInTransactionRollsBackOnlyToSavepointOnNestedError(t *testing.T) {
+	d := newTestSQLiteDBX(t)
+
+	err := d.RunInTransaction(context.Background(), nil, func(outer TX) error {
+		if _, err := outer.NamedExecContext(context.Background(), "INSERT INTO widgets (name) VALUES (:name)", map[string]interface{}{"name": "outer"}); err != nil {
+			return err
+		}
+
+		nestedErr := errors.New("nested failure")
+		err := outer.RunInTransaction(context.Background(), nil, func(inner TX) error {
+			if _, err := inner.NamedExecContext(context.Background(), "INSERT INTO widgets (name) VALUES (:name)", map[string]interface{}{"name": "inner"}); err != nil {
+				return err
+			}
+			return nestedErr
+		})
+		if !errors.Is(err, nestedErr) {
+			t.Fatalf("expected the nested RunInTransaction to surface nestedErr, got %v", err)
+		}
+
+		if got := countWidgets(t, outer); got != 1 {
+			t.Fatalf("expected only the outer insert to survive the nested rollback, got %d rows", got)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from outer RunInTransaction: %v", err)
+	}
+}
+
+func TestRunInTransactionPreservesFnErrorWhenRollbackToSavepointAlsoFails(t *testing.T) {
+	d := newTestSQLiteDBX(t)
+
+	err := d.RunInTransaction(context.Background(), nil, func(outer TX) error {
+		fnErr := errors.New("business logic failure")
+
+		err := outer.RunInTransaction(context.Background(), nil, func(inner TX) error {
+			// Ending the underlying *sql.Tx out from under the nested
+			// savepoint makes the later ROLLBACK TO SAVEPOINT fail with
+			// sql.ErrTxDone, since the transaction it belongs to is gone.
+			if commitErr := inner.(*tx).TX.Commit(); commitErr != nil {
+				t.Fatalf("failed to end underlying transaction early: %v", commitErr)
+			}
+			return fnErr
+		})
+
+		if !errors.Is(err, fnErr) {
+			t.Fatalf("expected the original fn error to survive a failed rollback-to-savepoint, got: %v", err)
+		}
+
+		return fmt.Errorf("abort outer transaction to avoid double-closing: %w", fnErr)
+	})
+	if err == nil {
+		t.Fatalf("expected an error from the outer transaction")
+	}
+}
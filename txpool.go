@@ -0,0 +1,36 @@
+package isqlx
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewMySQLDBXWithTxPool returns a DBX backed by two separate connection
+// pools: queryDB serves GetContext/SelectContext/NamedExecContext on the
+// top-level DBX, while txDB is reserved exclusively for Begin. This defends
+// against the well-known failure mode where a long-running transaction holds
+// the only free connection while a non-transactional query on the same pool
+// blocks forever waiting for one.
+func NewMySQLDBXWithTxPool(queryDB, txDB *sql.DB, tracer trace.Tracer, opts ...Option) DBX {
+	d := &dbx{
+		DB:        sqlx.NewDb(queryDB, "mysql"),
+		txDB:      sqlx.NewDb(txDB, "mysql"),
+		driver:    "mysql",
+		tracer:    tracer,
+		stmtCache: newNamedStmtCache(defaultStatementCacheSize),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// usesTxPool reports whether d routes Begin through a dedicated connection
+// pool rather than its main one. It exists so tests can assert the wiring
+// produced by NewMySQLDBXWithTxPool.
+func (d *dbx) usesTxPool() bool {
+	return d.txDB != nil
+}
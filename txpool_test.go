@@ -0,0 +1,74 @@
+package isqlx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewMySQLDBXWithTxPoolUsesTxPool(t *testing.T) {
+	queryDB, err := sql.Open("sqlite3", "file:query?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open queryDB: %v", err)
+	}
+	t.Cleanup(func() { _ = queryDB.Close() })
+
+	txDB, err := sql.Open("sqlite3", "file:tx?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed to open txDB: %v", err)
+	}
+	t.Cleanup(func() { _ = txDB.Close() })
+
+	tracer := sdktrace.NewTracerProvider().Tracer("isqlx_test")
+	d := NewMySQLDBXWithTxPool(queryDB, txDB, tracer)
+
+	if !d.(*dbx).usesTxPool() {
+		t.Fatalf("expected NewMySQLDBXWithTxPool to wire up a dedicated txDB")
+	}
+}
+
+// TestBeginDrawsFromTxPoolNotQueryPool is a regression guard for the
+// pool-exhaustion deadlock this was built to avoid: Begin must draw its
+// connection from txDB, not from the pool GetContext/SelectContext use, so a
+// saturated query pool can never starve transactions of a connection.
+func TestBeginDrawsFromTxPoolNotQueryPool(t *testing.T) {
+	queryDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open queryDB: %v", err)
+	}
+	t.Cleanup(func() { _ = queryDB.Close() })
+	queryDB.SetMaxOpenConns(1)
+
+	txDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open txDB: %v", err)
+	}
+	t.Cleanup(func() { _ = txDB.Close() })
+
+	if _, err := txDB.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tracer := sdktrace.NewTracerProvider().Tracer("isqlx_test")
+	d := NewMySQLDBXWithTxPool(queryDB, txDB, tracer)
+
+	// Saturate queryDB's single connection with an open, unfinished query.
+	rows, err := queryDB.QueryContext(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("unexpected error holding queryDB's only connection: %v", err)
+	}
+	t.Cleanup(func() { _ = rows.Close() })
+
+	tx, err := d.Begin(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected Begin to succeed off txDB even though queryDB's pool is saturated, got: %v", err)
+	}
+	defer tx.TxClose(context.Background())
+
+	if _, err := tx.NamedExecContext(context.Background(), "INSERT INTO widgets (name) VALUES (:name)", map[string]interface{}{"name": "w"}); err != nil {
+		t.Fatalf("unexpected error inserting within tx: %v", err)
+	}
+}